@@ -0,0 +1,444 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LoadOpts groups the inputs needed to build a RuntimeConfig: the default
+// config baked into the binary, any config files/directories passed on the
+// command line, ad-hoc overrides (e.g. -hcl flags), and a few environment
+// probes that are stubbed out in tests.
+type LoadOpts struct {
+	// DevMode, when set, relaxes validation that would otherwise require a
+	// DataDir to be configured.
+	DevMode *bool
+
+	// DefaultConfig is merged in first, before any ConfigFiles or
+	// Overrides, and typically comes from the config baked into the
+	// binary via DefaultSource.
+	DefaultConfig Source
+
+	// ConfigFiles are paths to either a single config file or a directory
+	// of config files, applied in the order given.
+	ConfigFiles []string
+
+	// ConfigFormat forces every entry in ConfigFiles to be parsed with
+	// the given format ("json", "hcl" or "yaml") regardless of file extension.
+	ConfigFormat string
+
+	// RemoteConfigs are fetched over HTTPS and merged in the same
+	// order-of-precedence slot as ConfigFiles, in the order given, so a
+	// fleet can bootstrap from a central config service alongside or
+	// instead of local files.
+	RemoteConfigs []HTTPSource
+
+	// HCL is a list of additional HCL snippets, such as those passed via
+	// repeated -hcl command line flags.
+	HCL []string
+
+	// FlagValues holds values supplied directly on the command line.
+	FlagValues Config
+
+	// Overrides are merged after every other Source (ConfigFiles,
+	// RemoteConfigs, HCL); only FlagValues is applied after them. They are
+	// usually used by tests to inject a few extra values.
+	Overrides []Source
+
+	// FS is used to discover and read ConfigFiles. It defaults to OS, a
+	// thin wrapper over the os package, but can be set to an in-memory
+	// fs.FS (fstest.MapFS in tests, a //go:embed FS for defaults baked
+	// into the binary, ...).
+	FS fs.FS
+
+	hostname       func() (string, error)
+	getPrivateIPv4 func() ([]*net.IPAddr, error)
+	getPublicIPv6  func() ([]*net.IPAddr, error)
+}
+
+// Builder accumulates Sources and turns them into a RuntimeConfig.
+type Builder struct {
+	opts     LoadOpts
+	Sources  []Source
+	Warnings []string
+}
+
+// LoadResult is returned by Load and bundles the resolved configuration
+// together with any warnings collected while building it.
+type LoadResult struct {
+	RuntimeConfig *RuntimeConfig
+	Warnings      []string
+}
+
+// Load builds a Builder from opts and immediately calls BuildAndValidate.
+func Load(opts LoadOpts) (LoadResult, error) {
+	b, err := NewBuilder(opts)
+	if err != nil {
+		return LoadResult{}, err
+	}
+
+	rt, err := b.BuildAndValidate()
+	if err != nil {
+		return LoadResult{Warnings: b.Warnings}, err
+	}
+	return LoadResult{RuntimeConfig: &rt, Warnings: b.Warnings}, nil
+}
+
+// NewBuilder walks opts.ConfigFiles, collecting a Source for every file it
+// should parse, in precedence order: DefaultConfig, ConfigFiles,
+// RemoteConfigs, HCL, Overrides.
+func NewBuilder(opts LoadOpts) (*Builder, error) {
+	if opts.FS == nil {
+		opts.FS = OS{}
+	}
+	if opts.hostname == nil {
+		opts.hostname = os.Hostname
+	}
+	if opts.getPrivateIPv4 == nil {
+		opts.getPrivateIPv4 = noopIPAddrs
+	}
+	if opts.getPublicIPv6 == nil {
+		opts.getPublicIPv6 = noopIPAddrs
+	}
+
+	b := &Builder{opts: opts}
+
+	if opts.DefaultConfig != nil {
+		b.Sources = append(b.Sources, opts.DefaultConfig)
+	}
+
+	for _, path := range opts.ConfigFiles {
+		sources, err := b.sourcesFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+		b.Sources = append(b.Sources, sources...)
+	}
+
+	for _, hs := range opts.RemoteConfigs {
+		hs := hs
+		b.Sources = append(b.Sources, &hs)
+	}
+
+	for i, hcl := range opts.HCL {
+		b.Sources = append(b.Sources, FileSource{
+			Name:   fmt.Sprintf("flag-%d", i),
+			Format: "hcl",
+			Data:   hcl,
+		})
+	}
+
+	b.Sources = append(b.Sources, opts.Overrides...)
+
+	return b, nil
+}
+
+// sourcesFromPath returns a Source for path if it is a file, or a Source for
+// every file directly inside it, sorted by name, if it is a directory.
+func (b *Builder) sourcesFromPath(p string) ([]Source, error) {
+	fi, err := fs.Stat(b.opts.FS, p)
+	if err != nil {
+		return nil, fmt.Errorf("config: error loading %s: %w", p, err)
+	}
+
+	if !fi.IsDir() {
+		src, ok, err := b.sourceFromFile(p, b.opts.ConfigFormat)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			b.Warnings = append(b.Warnings, fmt.Sprintf(
+				"skipping file %s, cannot determine config format", p))
+			return nil, nil
+		}
+		return []Source{src}, nil
+	}
+
+	entries, err := fs.ReadDir(b.opts.FS, p)
+	if err != nil {
+		return nil, fmt.Errorf("config: error listing %s: %w", p, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var sources []Source
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		full := path.Join(p, entry.Name())
+		src, ok, err := b.sourceFromFile(full, b.opts.ConfigFormat)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			b.Warnings = append(b.Warnings, fmt.Sprintf(
+				"skipping file %s, cannot determine config format", full))
+			continue
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+func (b *Builder) sourceFromFile(p, configFormat string) (Source, bool, error) {
+	if !shouldParseFile(p, configFormat) {
+		return nil, false, nil
+	}
+
+	data, err := fs.ReadFile(b.opts.FS, p)
+	if err != nil {
+		return nil, false, fmt.Errorf("config: error reading %s: %w", p, err)
+	}
+
+	format := configFormat
+	if format == "" {
+		format = formatFromFileExtension(p)
+	}
+
+	return FileSource{Name: p, Format: format, Data: string(data)}, true, nil
+}
+
+// shouldParseFile returns true if name looks like a config file that we know
+// how to parse, either because configFormat was set explicitly or because
+// name carries a recognized extension (.json, .hcl, .yaml, .yml).
+func shouldParseFile(name, configFormat string) bool {
+	if configFormat != "" {
+		return true
+	}
+	return formatFromFileExtension(name) != ""
+}
+
+// formatFromFileExtension maps a config file's extension to the Source
+// format used to decode it, returning "" if the extension isn't recognized.
+func formatFromFileExtension(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".hcl":
+		return "hcl"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return ""
+	}
+}
+
+// BuildAndValidate merges every Source in order of precedence into a single
+// Config, converts it into a RuntimeConfig, and validates the result. Any
+// non-fatal problems are appended to b.Warnings rather than returned as an
+// error.
+func (b *Builder) BuildAndValidate() (RuntimeConfig, error) {
+	var cfg Config
+	for _, src := range b.Sources {
+		parsed, unused, err := src.Parse()
+		if err != nil {
+			return RuntimeConfig{}, fmt.Errorf("config: error parsing %s: %w", src.Source(), err)
+		}
+		for _, key := range unused {
+			b.Warnings = append(b.Warnings, fmt.Sprintf(
+				"config: %s: unknown config key %q", src.Source(), key))
+		}
+		if w, ok := src.(Warner); ok {
+			b.Warnings = append(b.Warnings, w.Warnings()...)
+		}
+		cfg = mergeConfig(cfg, parsed)
+	}
+	cfg = mergeConfig(cfg, b.opts.FlagValues)
+
+	rt, err := b.build(cfg)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+
+	if err := b.validate(&rt); err != nil {
+		return RuntimeConfig{}, err
+	}
+
+	return rt, nil
+}
+
+// mergeConfig overlays every non-nil field of src onto dst and returns the
+// result, so that later sources win without clobbering earlier ones with
+// zero values.
+func mergeConfig(dst, src Config) Config {
+	if src.NodeName != nil {
+		dst.NodeName = src.NodeName
+	}
+	if src.NodeID != nil {
+		dst.NodeID = src.NodeID
+	}
+	if src.Datacenter != nil {
+		dst.Datacenter = src.Datacenter
+	}
+	if src.DataDir != nil {
+		dst.DataDir = src.DataDir
+	}
+	if src.Server != nil {
+		dst.Server = src.Server
+	}
+	if src.Bootstrap != nil {
+		dst.Bootstrap = src.Bootstrap
+	}
+	if src.BindAddr != nil {
+		dst.BindAddr = src.BindAddr
+	}
+	if src.AdvertiseAddr != nil {
+		dst.AdvertiseAddr = src.AdvertiseAddr
+	}
+	if src.AEInterval != nil {
+		dst.AEInterval = src.AEInterval
+	}
+	if src.CheckReapInterval != nil {
+		dst.CheckReapInterval = src.CheckReapInterval
+	}
+	if src.Limits != nil {
+		dst.Limits = src.Limits
+	}
+	return dst
+}
+
+func (b *Builder) build(cfg Config) (RuntimeConfig, error) {
+	rt := RuntimeConfig{
+		NodeName:      stringVal(cfg.NodeName),
+		NodeID:        stringVal(cfg.NodeID),
+		Datacenter:    stringVal(cfg.Datacenter),
+		DataDir:       stringVal(cfg.DataDir),
+		Server:        boolVal(cfg.Server),
+		Bootstrap:     boolVal(cfg.Bootstrap),
+		BindAddr:      stringVal(cfg.BindAddr),
+		AdvertiseAddr: stringVal(cfg.AdvertiseAddr),
+	}
+
+	if rt.NodeName == "" {
+		name, err := b.opts.hostname()
+		if err != nil {
+			return RuntimeConfig{}, fmt.Errorf("config: error determining hostname: %w", err)
+		}
+		rt.NodeName = name
+	}
+
+	aeInterval, err := durationVal("ae_interval", cfg.AEInterval, time.Minute)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	rt.AEInterval = aeInterval
+
+	checkReapInterval, err := durationVal("check_reap_interval", cfg.CheckReapInterval, 30*time.Second)
+	if err != nil {
+		return RuntimeConfig{}, err
+	}
+	rt.CheckReapInterval = checkReapInterval
+
+	if cfg.Limits != nil {
+		rt.Limits.HTTPMaxConnsPerClient = intVal(cfg.Limits.HTTPMaxConnsPerClient)
+	}
+
+	return rt, nil
+}
+
+// validate runs the struct-tag validation pass declared on RuntimeConfig
+// (see validate.go), routing DNS-discoverability problems to Warnings as it
+// always has, and aggregating everything else into a single error so an
+// operator sees every problem with their config in one pass instead of
+// fixing and re-running one mistake at a time.
+func (b *Builder) validate(rt *RuntimeConfig) error {
+	var fatal []error
+	for _, failure := range structFieldErrors(rt, boolVal(b.opts.DevMode)) {
+		if failure.tag == "nodename" {
+			b.Warnings = append(b.Warnings, validateNodeNameReason(rt.NodeName))
+			continue
+		}
+		fatal = append(fatal, failure.err)
+	}
+
+	if err := checkLimitsHTTPMaxConnsPerClient(rt); err != nil {
+		fatal = append(fatal, err)
+	}
+
+	return multierrorOrNil(fatal)
+}
+
+// limitsHTTPMaxConnsPerClientRlimitBuffer is the number of file descriptors
+// we try to keep in reserve, over and above limits.http_max_conns_per_client,
+// for everything else the agent opens (gossip, RPC, telemetry, ...).
+const limitsHTTPMaxConnsPerClientRlimitBuffer = 20
+
+func checkLimitsHTTPMaxConnsPerClient(rt *RuntimeConfig) error {
+	limit := rt.Limits.HTTPMaxConnsPerClient
+	if limit <= 0 {
+		return nil
+	}
+
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return nil
+	}
+
+	need := uint64(limit) + limitsHTTPMaxConnsPerClientRlimitBuffer
+	if rlimit.Cur < need {
+		return fmt.Errorf(
+			"system allows a max of %d file descriptors, but limits.http_max_conns_per_client: %d "+
+				"needs at least %d open file descriptors",
+			rlimit.Cur, limit, need)
+	}
+	return nil
+}
+
+func noopIPAddrs() ([]*net.IPAddr, error) {
+	return nil, nil
+}
+
+func stringVal(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func boolVal(v *bool) bool {
+	if v == nil {
+		return false
+	}
+	return *v
+}
+
+func intVal(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func durationVal(name string, v *string, def time.Duration) (time.Duration, error) {
+	if v == nil {
+		return def, nil
+	}
+	d, err := time.ParseDuration(*v)
+	if err != nil {
+		return 0, fmt.Errorf("config: %s: invalid duration: %w", name, err)
+	}
+	return d, nil
+}
+
+func pString(s string) *string {
+	return &s
+}
+
+func pBool(b bool) *bool {
+	return &b
+}
+
+func pInt(i int) *int {
+	return &i
+}
+
+func ipAddr(s string) *net.IPAddr {
+	return &net.IPAddr{IP: net.ParseIP(s)}
+}
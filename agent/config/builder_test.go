@@ -2,12 +2,11 @@ package config
 
 import (
 	"fmt"
-	"io/ioutil"
 	"net"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -45,6 +44,26 @@ func TestLoad(t *testing.T) {
 	require.Equal(t, 1*time.Millisecond, cfg.CheckReapInterval)
 }
 
+func TestLoad_YAML(t *testing.T) {
+	devMode := true
+	builderOpts := LoadOpts{
+		DevMode: &devMode,
+		DefaultConfig: FileSource{
+			Name:   "test",
+			Format: "yaml",
+			Data:   "node_name: bywater\ncheck_reap_interval: 2ms\n",
+		},
+	}
+
+	result, err := Load(builderOpts)
+	require.NoError(t, err)
+	require.Empty(t, result.Warnings)
+	cfg := result.RuntimeConfig
+	require.NotNil(t, cfg)
+	require.Equal(t, "bywater", cfg.NodeName)
+	require.Equal(t, 2*time.Millisecond, cfg.CheckReapInterval)
+}
+
 func TestShouldParseFile(t *testing.T) {
 	var testcases = []struct {
 		filename     string
@@ -55,7 +74,9 @@ func TestShouldParseFile(t *testing.T) {
 		{filename: "config.hcl", expected: true},
 		{filename: "config", configFormat: "hcl", expected: true},
 		{filename: "config.js", configFormat: "json", expected: true},
-		{filename: "config.yaml", expected: false},
+		{filename: "config.yaml", expected: true},
+		{filename: "config.yml", expected: true},
+		{filename: "config.toml", expected: false},
 	}
 
 	for _, tc := range testcases {
@@ -67,65 +88,53 @@ func TestShouldParseFile(t *testing.T) {
 }
 
 func TestNewBuilder_PopulatesSourcesFromConfigFiles(t *testing.T) {
-	paths := setupConfigFiles(t)
+	fsys, paths := setupConfigFiles()
 
-	b, err := NewBuilder(LoadOpts{ConfigFiles: paths})
+	b, err := NewBuilder(LoadOpts{FS: fsys, ConfigFiles: paths})
 	require.NoError(t, err)
 
 	expected := []Source{
 		FileSource{Name: paths[0], Format: "hcl", Data: "content a"},
 		FileSource{Name: paths[1], Format: "json", Data: "content b"},
-		FileSource{Name: filepath.Join(paths[3], "a.hcl"), Format: "hcl", Data: "content a"},
-		FileSource{Name: filepath.Join(paths[3], "b.json"), Format: "json", Data: "content b"},
+		FileSource{Name: paths[2], Format: "yaml", Data: "content c"},
+		FileSource{Name: path.Join(paths[3], "a.hcl"), Format: "hcl", Data: "content a"},
+		FileSource{Name: path.Join(paths[3], "b.json"), Format: "json", Data: "content b"},
+		FileSource{Name: path.Join(paths[3], "c.yaml"), Format: "yaml", Data: "content c"},
 	}
 	require.Equal(t, expected, b.Sources)
-	require.Len(t, b.Warnings, 2)
+	require.Empty(t, b.Warnings)
 }
 
 func TestNewBuilder_PopulatesSourcesFromConfigFiles_WithConfigFormat(t *testing.T) {
-	paths := setupConfigFiles(t)
+	fsys, paths := setupConfigFiles()
 
-	b, err := NewBuilder(LoadOpts{ConfigFiles: paths, ConfigFormat: "hcl"})
+	b, err := NewBuilder(LoadOpts{FS: fsys, ConfigFiles: paths, ConfigFormat: "hcl"})
 	require.NoError(t, err)
 
 	expected := []Source{
 		FileSource{Name: paths[0], Format: "hcl", Data: "content a"},
 		FileSource{Name: paths[1], Format: "hcl", Data: "content b"},
 		FileSource{Name: paths[2], Format: "hcl", Data: "content c"},
-		FileSource{Name: filepath.Join(paths[3], "a.hcl"), Format: "hcl", Data: "content a"},
-		FileSource{Name: filepath.Join(paths[3], "b.json"), Format: "hcl", Data: "content b"},
-		FileSource{Name: filepath.Join(paths[3], "c.yaml"), Format: "hcl", Data: "content c"},
+		FileSource{Name: path.Join(paths[3], "a.hcl"), Format: "hcl", Data: "content a"},
+		FileSource{Name: path.Join(paths[3], "b.json"), Format: "hcl", Data: "content b"},
+		FileSource{Name: path.Join(paths[3], "c.yaml"), Format: "hcl", Data: "content c"},
 	}
 	require.Equal(t, expected, b.Sources)
 }
 
-// TODO: this would be much nicer with gotest.tools/fs
-func setupConfigFiles(t *testing.T) []string {
-	t.Helper()
-	path, err := ioutil.TempDir("", t.Name())
-	require.NoError(t, err)
-	t.Cleanup(func() { os.RemoveAll(path) })
-
-	subpath := filepath.Join(path, "sub")
-	err = os.Mkdir(subpath, 0755)
-	require.NoError(t, err)
-
-	for _, dir := range []string{path, subpath} {
-		err = ioutil.WriteFile(filepath.Join(dir, "a.hcl"), []byte("content a"), 0644)
-		require.NoError(t, err)
-
-		err = ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte("content b"), 0644)
-		require.NoError(t, err)
-
-		err = ioutil.WriteFile(filepath.Join(dir, "c.yaml"), []byte("content c"), 0644)
-		require.NoError(t, err)
-	}
-	return []string{
-		filepath.Join(path, "a.hcl"),
-		filepath.Join(path, "b.json"),
-		filepath.Join(path, "c.yaml"),
-		subpath,
+// setupConfigFiles builds an in-memory fs.FS laid out like a typical
+// ConfigFiles setup: a couple of top-level files plus a "sub" directory
+// containing the same trio.
+func setupConfigFiles() (fstest.MapFS, []string) {
+	fsys := fstest.MapFS{
+		"a.hcl":      &fstest.MapFile{Data: []byte("content a")},
+		"b.json":     &fstest.MapFile{Data: []byte("content b")},
+		"c.yaml":     &fstest.MapFile{Data: []byte("content c")},
+		"sub/a.hcl":  &fstest.MapFile{Data: []byte("content a")},
+		"sub/b.json": &fstest.MapFile{Data: []byte("content b")},
+		"sub/c.yaml": &fstest.MapFile{Data: []byte("content c")},
 	}
+	return fsys, []string{"a.hcl", "b.json", "c.yaml", "sub"}
 }
 
 func TestBuilder_BuildAndValidate_NodeName(t *testing.T) {
@@ -187,6 +196,27 @@ func patchBuilderShims(b *Builder) {
 	}
 }
 
+func TestLoad_ErrorPreservesWarnings(t *testing.T) {
+	// server = true with no data_dir is a fatal validation error outside
+	// dev mode; typo_key is an unknown config key that only ever
+	// surfaces as a warning. BuildAndValidate collects the warning
+	// before it reaches validation and fails, so Load must still return
+	// it alongside the error instead of the zero LoadResult.
+	builderOpts := LoadOpts{
+		DefaultConfig: FileSource{
+			Name:   "test",
+			Format: "json",
+			Data:   `{"node_name": "bree", "server": true, "typo_key": true}`,
+		},
+	}
+
+	result, err := Load(builderOpts)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "data_dir is required for server agents")
+	require.Len(t, result.Warnings, 1)
+	require.Contains(t, result.Warnings[0], `unknown config key "typo_key"`)
+}
+
 func TestLoad_HTTPMaxConnsPerClientExceedsRLimit(t *testing.T) {
 	hcl := `
 		limits{
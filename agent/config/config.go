@@ -0,0 +1,30 @@
+package config
+
+// Config defines the former set of variables that were previously used to
+// configure consul using exported fields from the Config structure in
+// config.go. Any additions to this struct should be done with pointer values
+// and added to the mergeConfig/Builder functions so that overriding a
+// previous config source with an empty value does not clobber a prior
+// setting.
+type Config struct {
+	NodeName   *string `mapstructure:"node_name"`
+	NodeID     *string `mapstructure:"node_id"`
+	Datacenter *string `mapstructure:"datacenter"`
+	DataDir    *string `mapstructure:"data_dir"`
+
+	Server        *bool   `mapstructure:"server"`
+	Bootstrap     *bool   `mapstructure:"bootstrap"`
+	BindAddr      *string `mapstructure:"bind_addr"`
+	AdvertiseAddr *string `mapstructure:"advertise_addr"`
+
+	AEInterval        *string `mapstructure:"ae_interval"`
+	CheckReapInterval *string `mapstructure:"check_reap_interval"`
+
+	Limits *Limits `mapstructure:"limits"`
+}
+
+// Limits holds the various operator-tunable limits that apply across the
+// agent.
+type Limits struct {
+	HTTPMaxConnsPerClient *int `mapstructure:"http_max_conns_per_client"`
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"io/fs"
+	"os"
+)
+
+// OS is the default LoadOpts.FS: a thin wrapper over the os package so that
+// config discovery normally reads from the real file system, while still
+// letting tests and embedders substitute an in-memory fs.FS (fstest.MapFS,
+// a //go:embed FS, ...).
+type OS struct{}
+
+func (OS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (OS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (OS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
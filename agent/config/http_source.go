@@ -0,0 +1,188 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HTTPSource implements Source for config fetched over HTTPS from a central
+// config service (a Vault KV proxy, an S3 pre-signed URL, a Git raw
+// endpoint, ...). It supports conditional GET via ETag/If-Modified-Since,
+// and if CacheDir is set, falls back to the last successful response there
+// when the endpoint can't be reached rather than failing the whole Load.
+type HTTPSource struct {
+	// URL is the config endpoint to fetch. It is also used as the Source
+	// identifier in warnings and error messages.
+	URL string
+
+	// Format controls which decoder Parse uses, same as FileSource.Format.
+	Format string
+
+	// Header is sent with every request, e.g. for an Authorization token.
+	Header http.Header
+
+	// Timeout bounds the HTTP round trip. Zero means no timeout.
+	Timeout time.Duration
+
+	// TLSConfig configures the client's transport, e.g. to pin a custom
+	// CA or present a client certificate.
+	TLSConfig *tls.Config
+
+	// CacheDir, if set, is where the last successful response is cached
+	// on disk so it can be served if URL later becomes unreachable.
+	CacheDir string
+
+	warnings []string
+}
+
+// httpSourceCacheMeta is the ETag/Last-Modified pair stored alongside a
+// cached response so the next fetch can attempt a conditional GET.
+type httpSourceCacheMeta struct {
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+func (h *HTTPSource) Source() string {
+	return h.URL
+}
+
+func (h *HTTPSource) Parse() (Config, []string, error) {
+	h.warnings = nil
+	data, err := h.fetch()
+	if err != nil {
+		return Config{}, nil, err
+	}
+	return Parse(data, h.Format)
+}
+
+// Warnings reports any non-fatal problems seen by the most recent Parse,
+// such as falling back to a cached copy. It implements Warner.
+func (h *HTTPSource) Warnings() []string {
+	return h.warnings
+}
+
+// fetch returns the body to parse, preferring a fresh response from URL but
+// falling back to the cached copy under CacheDir if URL can't be reached or
+// returns an unexpected status.
+func (h *HTTPSource) fetch() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, h.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("config: error building request for %s: %w", h.URL, err)
+	}
+	for key, values := range h.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if meta, err := h.readCacheMeta(); err == nil {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	client := &http.Client{
+		Timeout:   h.Timeout,
+		Transport: &http.Transport{TLSClientConfig: h.TLSConfig},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return h.useCacheInstead(fmt.Errorf("config: error fetching %s: %w", h.URL, err))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		data, err := h.readCacheData()
+		if err != nil {
+			return "", fmt.Errorf("config: %s: server reported no change but no cached copy was found: %w", h.URL, err)
+		}
+		return data, nil
+	case http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return h.useCacheInstead(fmt.Errorf("config: error reading response from %s: %w", h.URL, err))
+		}
+		h.writeCache(string(body), resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+		return string(body), nil
+	default:
+		return h.useCacheInstead(fmt.Errorf("config: %s: unexpected response: %s", h.URL, resp.Status))
+	}
+}
+
+// useCacheInstead is called whenever fetching URL failed outright. It
+// records a warning and serves the cached copy if one exists, or returns
+// fetchErr if not.
+func (h *HTTPSource) useCacheInstead(fetchErr error) (string, error) {
+	data, err := h.readCacheData()
+	if err != nil {
+		return "", fetchErr
+	}
+	h.warnings = append(h.warnings, fmt.Sprintf(
+		"config: %s: using cached copy from %s: %s", h.URL, h.CacheDir, fetchErr))
+	return data, nil
+}
+
+func (h *HTTPSource) cachePaths() (data, meta string) {
+	sum := sha256.Sum256([]byte(h.URL))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(h.CacheDir, key+".data"), filepath.Join(h.CacheDir, key+".meta.json")
+}
+
+func (h *HTTPSource) readCacheData() (string, error) {
+	if h.CacheDir == "" {
+		return "", errors.New("no cache dir configured")
+	}
+	dataPath, _ := h.cachePaths()
+	raw, err := os.ReadFile(dataPath)
+	return string(raw), err
+}
+
+func (h *HTTPSource) readCacheMeta() (httpSourceCacheMeta, error) {
+	if h.CacheDir == "" {
+		return httpSourceCacheMeta{}, errors.New("no cache dir configured")
+	}
+	_, metaPath := h.cachePaths()
+	raw, err := os.ReadFile(metaPath)
+	if err != nil {
+		return httpSourceCacheMeta{}, err
+	}
+	var meta httpSourceCacheMeta
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return httpSourceCacheMeta{}, err
+	}
+	return meta, nil
+}
+
+// writeCache persists a successful response so it can be served later if
+// URL becomes unreachable. Failures to write the cache are not fatal: the
+// fetch it's caching already succeeded.
+func (h *HTTPSource) writeCache(data, etag, lastModified string) {
+	if h.CacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(h.CacheDir, 0o755); err != nil {
+		return
+	}
+	dataPath, metaPath := h.cachePaths()
+	if err := os.WriteFile(dataPath, []byte(data), 0o644); err != nil {
+		return
+	}
+	meta, err := json.Marshal(httpSourceCacheMeta{ETag: etag, LastModified: lastModified})
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(metaPath, meta, 0o644)
+}
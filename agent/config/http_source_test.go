@@ -0,0 +1,92 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_RemoteConfigsPrecedence(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node_name": "remote"}`))
+	}))
+	defer srv.Close()
+
+	devMode := true
+	fsys := fstest.MapFS{
+		"config.hcl": &fstest.MapFile{Data: []byte(`node_name = "file"`)},
+	}
+
+	// RemoteConfigs merges in the same precedence slot as ConfigFiles,
+	// after ConfigFiles but before Overrides, so a config service value
+	// beats a local file but a test override still wins.
+	result, err := Load(LoadOpts{
+		DevMode:       &devMode,
+		FS:            fsys,
+		ConfigFiles:   []string{"config.hcl"},
+		RemoteConfigs: []HTTPSource{{URL: srv.URL, Format: "json"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "remote", result.RuntimeConfig.NodeName)
+
+	result, err = Load(LoadOpts{
+		DevMode:       &devMode,
+		FS:            fsys,
+		ConfigFiles:   []string{"config.hcl"},
+		RemoteConfigs: []HTTPSource{{URL: srv.URL, Format: "json"}},
+		Overrides: []Source{
+			FileSource{Name: "overrides", Format: "json", Data: `{"node_name": "override"}`},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "override", result.RuntimeConfig.NodeName)
+}
+
+func TestHTTPSource_Parse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node_name": "bree"}`))
+	}))
+	defer srv.Close()
+
+	src := &HTTPSource{URL: srv.URL, Format: "json"}
+	cfg, unused, err := src.Parse()
+	require.NoError(t, err)
+	require.Empty(t, unused)
+	require.Equal(t, "bree", *cfg.NodeName)
+	require.Empty(t, src.Warnings())
+}
+
+func TestHTTPSource_Parse_FallsBackToCacheWhenUnreachable(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node_name": "bree"}`))
+	}))
+
+	src := &HTTPSource{URL: srv.URL, Format: "json", CacheDir: cacheDir}
+	cfg, _, err := src.Parse()
+	require.NoError(t, err)
+	require.Equal(t, "bree", *cfg.NodeName)
+
+	srv.Close()
+
+	src = &HTTPSource{URL: srv.URL, Format: "json", CacheDir: cacheDir}
+	cfg, _, err = src.Parse()
+	require.NoError(t, err)
+	require.Equal(t, "bree", *cfg.NodeName)
+	require.Len(t, src.Warnings(), 1)
+	require.Contains(t, src.Warnings()[0], "using cached copy")
+}
+
+func TestHTTPSource_Parse_UnreachableWithoutCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	src := &HTTPSource{URL: url, Format: "json"}
+	_, _, err := src.Parse()
+	require.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// RuntimeConfig is the fully resolved, flattened configuration used to
+// actually run the agent. It is built by merging every Config source in
+// order of precedence and filling in defaults for anything left unset.
+//
+// The validate struct tags below are the single source of truth for the
+// struct-tag validation pass run by Builder.validate; see validate.go for
+// how each tag is enforced and translated into a warning or error.
+type RuntimeConfig struct {
+	NodeName   string `validate:"nodename"`
+	NodeID     string
+	Datacenter string `validate:"omitempty,datacenter"`
+	DataDir    string `validate:"required_if_server"`
+
+	Server        bool
+	Bootstrap     bool
+	BindAddr      string `validate:"omitempty,ip"`
+	AdvertiseAddr string `validate:"omitempty,ip"`
+
+	AEInterval        time.Duration `validate:"gt=0"`
+	CheckReapInterval time.Duration `validate:"gt=0"`
+
+	Limits RuntimeLimits
+}
+
+// RuntimeLimits is the resolved form of Limits.
+type RuntimeLimits struct {
+	HTTPMaxConnsPerClient int
+}
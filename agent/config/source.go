@@ -0,0 +1,161 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+)
+
+// Source parses a Config from some underlying representation (a file on
+// disk, a literal string, a remote endpoint, ...) and reports which parts of
+// that representation went unused so the Builder can warn about typos in
+// operator-supplied configuration.
+type Source interface {
+	// Source returns an identifier for this source, used in warnings and
+	// error messages to help operators find the offending config.
+	Source() string
+
+	// Parse decodes the underlying representation into a Config, and
+	// returns any keys in that representation that were not recognized.
+	Parse() (Config, []string, error)
+}
+
+// Warner is an optional interface a Source can implement to surface
+// non-fatal problems discovered while parsing that don't fit the unknown-key
+// warnings Parse already reports, such as HTTPSource falling back to a
+// cached copy. BuildAndValidate appends these to Builder.Warnings right
+// after calling Parse.
+type Warner interface {
+	Warnings() []string
+}
+
+// FileSource implements Source for config that lives in a file on disk (or
+// was already read into memory from one). Format controls which decoder is
+// used and is normally derived from the file extension.
+type FileSource struct {
+	Name   string
+	Format string
+	Data   string
+}
+
+func (f FileSource) Source() string {
+	return f.Name
+}
+
+func (f FileSource) Parse() (Config, []string, error) {
+	return Parse(f.Data, f.Format)
+}
+
+// Parse decodes data in the given format ("json", "hcl", or "yaml") into a
+// Config, returning any fields that were present in data but have no
+// corresponding Config field.
+func Parse(data string, format string) (Config, []string, error) {
+	var raw map[string]interface{}
+	var err error
+
+	switch format {
+	case "json":
+		raw, err = decodeJSONHook(data)
+	case "hcl":
+		raw, err = decodeHCLHook(data)
+	case "yaml", "yml":
+		raw, err = decodeYAMLHook(data)
+	default:
+		return Config{}, nil, fmt.Errorf("invalid format: %s", format)
+	}
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	var md mapstructure.Metadata
+	var cfg Config
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			unwrapHCLBlockHook,
+			mapstructure.StringToTimeDurationHookFunc(),
+		),
+		Metadata:         &md,
+		Result:           &cfg,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if err := decoder.Decode(raw); err != nil {
+		return Config{}, nil, fmt.Errorf("error decoding config: %w", err)
+	}
+	return cfg, md.Unused, nil
+}
+
+// unwrapHCLBlockHook undoes hcl's habit of decoding a block, such as
+// `limits { ... }`, as a one-element []map[string]interface{} instead of a
+// plain map, so that the normal mapstructure struct decoding below doesn't
+// need to special-case it. The destination field can be a struct, a map, or
+// (as with Config.Limits) a pointer to either, so this only checks the
+// source shape rather than requiring `to` be reflect.Map.
+func unwrapHCLBlockHook(from, to reflect.Kind, data interface{}) (interface{}, error) {
+	if from != reflect.Slice {
+		return data, nil
+	}
+	v := reflect.ValueOf(data)
+	if v.Len() != 1 {
+		return data, nil
+	}
+	return v.Index(0).Interface(), nil
+}
+
+func decodeHCLHook(data string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, data); err != nil {
+		return nil, fmt.Errorf("error parsing hcl: %w", err)
+	}
+	return raw, nil
+}
+
+func decodeJSONHook(data string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("error parsing json: %w", err)
+	}
+	return raw, nil
+}
+
+// decodeYAMLHook parses YAML config in the same intermediate
+// map[string]interface{} shape that the JSON and HCL decoders produce, so it
+// can flow through the same mapstructure normalization path. yaml.v2 decodes
+// mappings as map[interface{}]interface{}, so nested maps are walked and
+// converted to map[string]interface{} to match.
+func decodeYAMLHook(data string) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("error parsing yaml: %w", err)
+	}
+	return stringifyYAMLMapKeys(raw).(map[string]interface{}), nil
+}
+
+func stringifyYAMLMapKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = stringifyYAMLMapKeys(val)
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = stringifyYAMLMapKeys(val)
+		}
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = stringifyYAMLMapKeys(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
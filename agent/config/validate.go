@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/go-multierror"
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// maxNodeNameLen is the longest a node name can be and still be
+// discoverable via DNS.
+const maxNodeNameLen = 63
+
+// structValidator drives the struct-tag validation pass declared on
+// RuntimeConfig. It is built once, as the validator package recommends, and
+// has the Consul-specific tags used by RuntimeConfig registered on it.
+var structValidator = newStructValidator()
+
+func newStructValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("nodename", validateNodeNameTag)
+	v.RegisterValidation("datacenter", validateDatacenterTag)
+	v.RegisterValidation("required_if_server", validateRequiredIfServerTag)
+	return v
+}
+
+// fieldFailure pairs a failed validate tag with the error it should be
+// reported as, so the caller can decide whether it belongs in Warnings or
+// should abort the build.
+type fieldFailure struct {
+	tag string
+	err error
+}
+
+// structFieldErrors runs the struct-tag validation pass over rt and
+// translates each failure into an error consistent with the ones
+// BuildAndValidate returned before struct tags existed, so switching the
+// validation mechanism doesn't change what operators see.
+//
+// devMode mirrors the long-standing exemption that lets `consul agent -dev`
+// run without a DataDir even though DataDir is otherwise required for
+// servers; that exemption depends on LoadOpts, not on anything RuntimeConfig
+// itself carries, so it's applied here rather than as a validate tag.
+func structFieldErrors(rt *RuntimeConfig, devMode bool) []fieldFailure {
+	err := structValidator.Struct(rt)
+	if err == nil {
+		return nil
+	}
+
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []fieldFailure{{err: err}}
+	}
+
+	var failures []fieldFailure
+	for _, fe := range verrs {
+		if devMode && fe.StructField() == "DataDir" && fe.Tag() == "required_if_server" {
+			continue
+		}
+		failures = append(failures, fieldFailure{tag: fe.Tag(), err: translateFieldError(fe)})
+	}
+	return failures
+}
+
+// translateFieldError turns a validator.FieldError into the same style of
+// message the hand-written checks it replaced used to produce.
+func translateFieldError(fe validator.FieldError) error {
+	switch fe.StructField() {
+	case "DataDir":
+		return fmt.Errorf("config: data_dir is required for server agents")
+	case "BindAddr":
+		return fmt.Errorf("config: bind_addr: invalid IP address: %q", fe.Value())
+	case "AdvertiseAddr":
+		return fmt.Errorf("config: advertise_addr: invalid IP address: %q", fe.Value())
+	case "Datacenter":
+		return fmt.Errorf("config: datacenter: invalid datacenter name: %q", fe.Value())
+	case "AEInterval":
+		return fmt.Errorf("config: ae_interval: must be greater than 0")
+	case "CheckReapInterval":
+		return fmt.Errorf("config: check_reap_interval: must be greater than 0")
+	default:
+		return fmt.Errorf("config: %s: failed %q validation", fe.StructField(), fe.Tag())
+	}
+}
+
+// validateNodeNameTag implements the "nodename" validate tag: the DNS-safe
+// subset of hostname characters, capped at maxNodeNameLen bytes, that
+// RuntimeConfig.NodeName must satisfy to be discoverable via DNS.
+func validateNodeNameTag(fl validator.FieldLevel) bool {
+	return validateNodeNameReason(fl.Field().String()) == ""
+}
+
+// validateNodeNameReason returns a warning describing why name won't be
+// discoverable via DNS, or "" if name is fine.
+func validateNodeNameReason(name string) string {
+	if len(name) > maxNodeNameLen {
+		return fmt.Sprintf(
+			"Node name %q will not be discoverable via DNS due to it being too long. "+
+				"Valid lengths are between 1 and %d bytes.", name, maxNodeNameLen)
+	}
+
+	for _, r := range name {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-') {
+			return fmt.Sprintf(
+				"Node name %q will not be discoverable via DNS due to invalid characters. "+
+					"Valid characters include all alphanumerics and dashes.", name)
+		}
+	}
+	return ""
+}
+
+// validateDatacenterTag implements the "datacenter" validate tag: datacenter
+// names are used directly in DNS names and gossip tags, so they're
+// restricted to lowercase alphanumerics and dashes.
+func validateDatacenterTag(fl validator.FieldLevel) bool {
+	dc := fl.Field().String()
+	for _, r := range dc {
+		if !((r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateRequiredIfServerTag implements the "required_if_server" validate
+// tag: the field it's on must be non-empty whenever the struct's Server
+// field is true. required_if isn't a builtin tag in validator.v9 (it was
+// added in v10), so this reproduces it directly; it's the struct-tag
+// equivalent of the `rt.Server && rt.DataDir == ""` check BuildAndValidate
+// used to make by hand.
+func validateRequiredIfServerTag(fl validator.FieldLevel) bool {
+	if fl.Field().String() != "" {
+		return true
+	}
+	parent := fl.Parent()
+	if parent.Kind() == reflect.Ptr {
+		parent = parent.Elem()
+	}
+	server := parent.FieldByName("Server")
+	return !server.IsValid() || !server.Bool()
+}
+
+// multierrorOrNil aggregates errs into a single error so that callers see
+// every validation failure at once instead of just the first one, or nil if
+// errs is empty.
+func multierrorOrNil(errs []error) error {
+	var result *multierror.Error
+	for _, err := range errs {
+		result = multierror.Append(result, err)
+	}
+	return result.ErrorOrNil()
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_BuildAndValidate_RequiredIfServer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		devMode   bool
+		server    bool
+		dataDir   string
+		expectErr string
+	}{
+		{name: "server without data_dir fails", server: true, expectErr: "data_dir is required for server agents"},
+		{name: "server with data_dir passes", server: true, dataDir: "dir"},
+		{name: "client without data_dir passes", server: false},
+		{name: "dev mode exempts server without data_dir", devMode: true, server: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := LoadOpts{
+				FlagValues: Config{
+					NodeName: pString("valid"),
+					Server:   pBool(tc.server),
+				},
+			}
+			if tc.dataDir != "" {
+				opts.FlagValues.DataDir = pString(tc.dataDir)
+			}
+			if tc.devMode {
+				opts.DevMode = pBool(true)
+			}
+
+			b, err := NewBuilder(opts)
+			require.NoError(t, err)
+			patchBuilderShims(b)
+			_, err = b.BuildAndValidate()
+
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildAndValidate_Addresses(t *testing.T) {
+	testCases := []struct {
+		name      string
+		addr      string
+		expectErr string
+	}{
+		{name: "empty is fine", addr: ""},
+		{name: "valid IP passes", addr: "127.0.0.1"},
+		{name: "garbage fails", addr: "not-an-ip", expectErr: "invalid IP address"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := NewBuilder(LoadOpts{
+				FlagValues: Config{
+					NodeName: pString("valid"),
+					DataDir:  pString("dir"),
+				},
+			})
+			require.NoError(t, err)
+			patchBuilderShims(b)
+			if tc.addr != "" {
+				b.opts.FlagValues.BindAddr = pString(tc.addr)
+				b.opts.FlagValues.AdvertiseAddr = pString(tc.addr)
+			}
+			_, err = b.BuildAndValidate()
+
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildAndValidate_Datacenter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		datacenter string
+		expectErr  string
+	}{
+		{name: "empty is fine", datacenter: ""},
+		{name: "lowercase with dash passes", datacenter: "us-east-1"},
+		{name: "uppercase fails", datacenter: "DC1", expectErr: "invalid datacenter name"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{
+				NodeName: pString("valid"),
+				DataDir:  pString("dir"),
+			}
+			if tc.datacenter != "" {
+				cfg.Datacenter = pString(tc.datacenter)
+			}
+
+			b, err := NewBuilder(LoadOpts{FlagValues: cfg})
+			require.NoError(t, err)
+			patchBuilderShims(b)
+			_, err = b.BuildAndValidate()
+
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildAndValidate_IntervalsMustBePositive(t *testing.T) {
+	testCases := []struct {
+		name              string
+		aeInterval        string
+		checkReapInterval string
+		expectErr         string
+	}{
+		{name: "defaults pass"},
+		{name: "zero ae_interval fails", aeInterval: "0s", expectErr: "ae_interval: must be greater than 0"},
+		{name: "zero check_reap_interval fails", checkReapInterval: "0s", expectErr: "check_reap_interval: must be greater than 0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{
+				NodeName: pString("valid"),
+				DataDir:  pString("dir"),
+			}
+			if tc.aeInterval != "" {
+				cfg.AEInterval = pString(tc.aeInterval)
+			}
+			if tc.checkReapInterval != "" {
+				cfg.CheckReapInterval = pString(tc.checkReapInterval)
+			}
+
+			b, err := NewBuilder(LoadOpts{FlagValues: cfg})
+			require.NoError(t, err)
+			patchBuilderShims(b)
+			_, err = b.BuildAndValidate()
+
+			if tc.expectErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectErr)
+			}
+		})
+	}
+}
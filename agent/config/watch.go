@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounceInterval coalesces bursts of file system events (editors
+// commonly write a file more than once per save) into a single reload.
+const watchDebounceInterval = 200 * time.Millisecond
+
+// ReloadResult is delivered on a Watcher's channel every time a watched
+// config file changes and the sources are rebuilt. Err and Warnings mirror
+// the return values of BuildAndValidate, so a bad edit is reported here
+// rather than silently discarded.
+type ReloadResult struct {
+	RuntimeConfig RuntimeConfig
+	Warnings      []string
+	Err           error
+}
+
+// Watcher watches every path in a LoadOpts.ConfigFiles for changes and
+// re-runs the source->RuntimeConfig pipeline whenever one changes. It never
+// mutates a RuntimeConfig a caller is already using; callers read Reload()
+// and decide for themselves whether and how to swap in the new config.
+type Watcher struct {
+	opts    LoadOpts
+	fsw     *fsnotify.Watcher
+	reloads chan ReloadResult
+	stop    chan struct{}
+
+	// sendMu serializes send, since reload runs on its own goroutine (via
+	// time.AfterFunc) while run's goroutine can concurrently send a
+	// ReloadResult of its own for an fsnotify error. Without it, the
+	// drain-then-send sequence in send can interleave across the two
+	// goroutines and leave one of them blocked forever on a full channel
+	// nobody is about to read.
+	sendMu sync.Mutex
+}
+
+// Watch performs an initial Load and, if it succeeds, starts watching every
+// path in opts.ConfigFiles for changes.
+func Watch(opts LoadOpts) (*Watcher, LoadResult, error) {
+	result, err := Load(opts)
+	if err != nil {
+		return nil, LoadResult{}, err
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, LoadResult{}, fmt.Errorf("config: error starting watcher: %w", err)
+	}
+	for _, p := range opts.ConfigFiles {
+		if err := fsw.Add(p); err != nil {
+			fsw.Close()
+			return nil, LoadResult{}, fmt.Errorf("config: error watching %s: %w", p, err)
+		}
+	}
+
+	w := &Watcher{
+		opts:    opts,
+		fsw:     fsw,
+		reloads: make(chan ReloadResult, 1),
+		stop:    make(chan struct{}),
+	}
+	go w.run()
+	return w, result, nil
+}
+
+// Reload returns the channel that ReloadResults are delivered on.
+func (w *Watcher) Reload() <-chan ReloadResult {
+	return w.reloads
+}
+
+// Stop stops watching ConfigFiles and releases the underlying OS resources.
+func (w *Watcher) Stop() error {
+	close(w.stop)
+	return w.fsw.Close()
+}
+
+func (w *Watcher) run() {
+	var debounce *time.Timer
+	for {
+		select {
+		case <-w.stop:
+			return
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.send(ReloadResult{Err: err})
+		case _, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if debounce == nil {
+				debounce = time.AfterFunc(watchDebounceInterval, w.reload)
+			} else {
+				debounce.Reset(watchDebounceInterval)
+			}
+		}
+	}
+}
+
+// reload re-runs the full source->RuntimeConfig pipeline and then re-walks
+// any directory sources so that files created or removed since the last
+// reload are picked up by future events. rewatch runs even when Load fails,
+// since an atomic rename-over-original (vim, Ansible/Terraform "atomic"
+// writes, ...) makes fsnotify report a REMOVE of the old inode regardless of
+// whether the new content validates; skipping rewatch on error would leave
+// that path unwatched forever, silently dropping every edit after the first
+// bad one.
+func (w *Watcher) reload() {
+	result, err := Load(w.opts)
+	w.rewatch()
+	if err != nil {
+		w.send(ReloadResult{Err: err, Warnings: result.Warnings})
+		return
+	}
+	w.send(ReloadResult{RuntimeConfig: *result.RuntimeConfig, Warnings: result.Warnings})
+}
+
+func (w *Watcher) rewatch() {
+	b, err := NewBuilder(w.opts)
+	if err != nil {
+		return
+	}
+	for _, src := range b.Sources {
+		if f, ok := src.(FileSource); ok {
+			// Add is a no-op if the path is already watched.
+			w.fsw.Add(f.Name)
+		}
+	}
+}
+
+// send delivers r on w.reloads, dropping a stale, unread result rather than
+// blocking the watch loop on a slow consumer.
+func (w *Watcher) send(r ReloadResult) {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	select {
+	case w.reloads <- r:
+	default:
+		select {
+		case <-w.reloads:
+		default:
+		}
+		w.reloads <- r
+	}
+}
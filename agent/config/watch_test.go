@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch_ReloadsOnFileChange(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.hcl")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`node_name = "bree"`), 0644))
+
+	devMode := true
+	opts := LoadOpts{
+		DevMode:     &devMode,
+		ConfigFiles: []string{cfgPath},
+	}
+
+	w, result, err := Watch(opts)
+	require.NoError(t, err)
+	defer w.Stop()
+	require.Equal(t, "bree", result.RuntimeConfig.NodeName)
+
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`node_name = "rivendell"`), 0644))
+
+	select {
+	case reload := <-w.Reload():
+		require.NoError(t, reload.Err)
+		require.Equal(t, "rivendell", reload.RuntimeConfig.NodeName)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
+
+func TestWatch_BadEditSurfacesErrAndWarnings(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.hcl")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`node_name = "bree"`), 0644))
+
+	devMode := true
+	opts := LoadOpts{
+		DevMode:     &devMode,
+		ConfigFiles: []string{cfgPath},
+	}
+
+	w, result, err := Watch(opts)
+	require.NoError(t, err)
+	defer w.Stop()
+	require.Equal(t, "bree", result.RuntimeConfig.NodeName)
+
+	// server = true with no data_dir is a fatal validation error outside
+	// dev mode, so flipping devMode off and adding it makes the next
+	// reload fail; typo_key is an unknown config key that only ever
+	// surfaces as a warning.
+	devModeOff := false
+	w.opts.DevMode = &devModeOff
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`node_name = "bree"
+server = true
+typo_key = true
+`), 0644))
+
+	w.reload()
+
+	select {
+	case reload := <-w.Reload():
+		require.Error(t, reload.Err)
+		require.Contains(t, reload.Err.Error(), "data_dir is required for server agents")
+		require.Len(t, reload.Warnings, 1)
+		require.Contains(t, reload.Warnings[0], `unknown config key "typo_key"`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
+
+func TestWatch_SurvivesBadEditViaAtomicRename(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.hcl")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`node_name = "bree"`), 0644))
+
+	devMode := true
+	opts := LoadOpts{
+		DevMode:     &devMode,
+		ConfigFiles: []string{cfgPath},
+	}
+
+	w, result, err := Watch(opts)
+	require.NoError(t, err)
+	defer w.Stop()
+	require.Equal(t, "bree", result.RuntimeConfig.NodeName)
+
+	// Editors and config-management tools commonly "atomically" replace a
+	// file by writing to a scratch path and renaming it over the
+	// original, which fsnotify reports as a REMOVE of the old inode
+	// rather than a WRITE. A bad edit delivered this way must not leave
+	// the path unwatched: rewatch has to run even though this reload
+	// fails.
+	atomicWriteFile(t, cfgPath, []byte(`node_name = "bree"
+ae_interval = "not-a-duration"
+`))
+
+	select {
+	case reload := <-w.Reload():
+		require.Error(t, reload.Err)
+		require.Contains(t, reload.Err.Error(), "ae_interval: invalid duration")
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload of bad edit")
+	}
+
+	atomicWriteFile(t, cfgPath, []byte(`node_name = "rivendell"`))
+
+	select {
+	case reload := <-w.Reload():
+		require.NoError(t, reload.Err)
+		require.Equal(t, "rivendell", reload.RuntimeConfig.NodeName)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload of good edit after a bad one")
+	}
+}
+
+// atomicWriteFile replaces path's contents the way editors and config
+// management tools do: write to a scratch file in the same directory, then
+// rename it over the original, so fsnotify sees a REMOVE of the old inode
+// instead of a WRITE.
+func atomicWriteFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	tmp := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0644))
+	require.NoError(t, os.Rename(tmp, path))
+}